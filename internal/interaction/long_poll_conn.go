@@ -0,0 +1,191 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// longPollConn is a LongConn backed by plain HTTP: it pulls batched
+// GeneralWsResp frames from an "/events" endpoint and posts GeneralWsReq
+// frames to "/socket".
+type longPollConn struct {
+	client  *http.Client
+	header  http.Header
+	pullURL string
+	pushURL string
+	pending chan []byte
+
+	mutex  sync.Mutex
+	closed bool
+	stopCh chan struct{}
+}
+
+func newLongPollConn() *longPollConn {
+	return &longPollConn{
+		client:  &http.Client{},
+		pending: make(chan []byte, 100),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+func (c *longPollConn) IsNil() bool {
+	return c == nil
+}
+
+func (c *longPollConn) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.stopCh)
+	return nil
+}
+
+// Dial resets closed/stopCh so the conn can be reused across ReConn calls,
+// does the first pull, then starts the background poll loop.
+func (c *longPollConn) Dial(urlStr string, header http.Header) (*http.Response, error) {
+	c.mutex.Lock()
+	c.closed = false
+	c.stopCh = make(chan struct{})
+	c.mutex.Unlock()
+
+	c.header = header
+	c.pushURL = urlStr
+	c.pullURL = strings.Replace(urlStr, "/socket", "/events", 1)
+	resp, body, err := c.pull()
+	if err != nil {
+		return resp, err
+	}
+	if len(body) > 0 {
+		c.enqueue(body)
+	}
+	go c.pollLoop()
+	return resp, nil
+}
+
+func (c *longPollConn) pollLoop() {
+	c.mutex.Lock()
+	stop := c.stopCh
+	c.mutex.Unlock()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		_, body, err := c.pull()
+		if err != nil {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		if len(body) > 0 {
+			c.enqueue(body)
+		}
+	}
+}
+
+func (c *longPollConn) pull() (*http.Response, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.pullURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header = c.header
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return resp, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil, errors.New("longPollConn: pull failed, status " + resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, body, nil
+}
+
+func (c *longPollConn) enqueue(frame []byte) {
+	select {
+	case c.pending <- frame:
+	default:
+		// The reader is falling behind; drop the oldest batch rather than
+		// block the poll loop.
+		<-c.pending
+		c.pending <- frame
+	}
+}
+
+// Ping messages are a no-op here; there's no persistent connection to keep alive between polls.
+func (c *longPollConn) WriteMessage(messageType int, data []byte) error {
+	if messageType == websocket.PingMessage {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, c.pushURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header = c.header
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("longPollConn: push failed, status " + resp.Status)
+	}
+	return nil
+}
+
+func (c *longPollConn) ReadMessage() (int, []byte, error) {
+	c.mutex.Lock()
+	stop := c.stopCh
+	c.mutex.Unlock()
+	select {
+	case <-stop:
+		return 0, nil, errors.New("longPollConn: closed")
+	default:
+	}
+	select {
+	case frame := <-c.pending:
+		return websocket.BinaryMessage, frame, nil
+	case <-stop:
+		return 0, nil, errors.New("longPollConn: closed")
+	}
+}
+
+func (c *longPollConn) SetReadTimeout(timeout int) error {
+	c.client.Timeout = time.Duration(timeout) * time.Second
+	return nil
+}
+
+func (c *longPollConn) SetWriteTimeout(timeout int) error {
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLongPollConnSurvivesCloseThenRedial(t *testing.T) {
+	var pulls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			n := atomic.AddInt32(&pulls, 1)
+			fmt.Fprintf(w, "frame-%d", n)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newLongPollConn()
+	if _, err := c.Dial(srv.URL+"/socket", http.Header{}); err != nil {
+		t.Fatalf("first Dial failed: %v", err)
+	}
+	if _, first, err := c.ReadMessage(); err != nil || len(first) == 0 {
+		t.Fatalf("first ReadMessage: frame=%q err=%v", first, err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, _, err := c.ReadMessage(); err == nil {
+		t.Fatal("expected ReadMessage to return an error once closed")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("second Close must be a no-op, got: %v", err)
+	}
+
+	if _, err := c.Dial(srv.URL+"/socket", http.Header{}); err != nil {
+		t.Fatalf("re-dial after Close failed: %v", err)
+	}
+	defer c.Close()
+
+	if _, second, err := c.ReadMessage(); err != nil || len(second) == 0 {
+		t.Fatalf("poll loop did not resume after re-dial: frame=%q err=%v", second, err)
+	}
+}
+
+func TestLongPollConnReadMessageUnblocksOnClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newLongPollConn()
+	if _, err := c.Dial(srv.URL+"/socket", http.Header{}); err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.ReadMessage()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("ReadMessage returned early with err=%v before Close", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected ReadMessage to return an error after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadMessage did not unblock after Close")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// timingWheel is a hashed timing wheel: O(1) insert/cancel, one ticker
+// goroutine shared across every scheduled delay.
+type timingWheel struct {
+	mu      sync.Mutex
+	buckets []*list.List
+	tick    time.Duration
+	pos     int
+	ticker  *time.Ticker
+	stopCh  chan struct{}
+}
+
+type wheelTask struct {
+	remainingRounds int
+	fn              func()
+	cancelled       bool
+}
+
+func newTimingWheel(tick time.Duration, slots int) *timingWheel {
+	w := &timingWheel{
+		buckets: make([]*list.List, slots),
+		tick:    tick,
+		stopCh:  make(chan struct{}),
+	}
+	for i := range w.buckets {
+		w.buckets[i] = list.New()
+	}
+	w.ticker = time.NewTicker(tick)
+	go w.run()
+	return w
+}
+
+func (w *timingWheel) run() {
+	for {
+		select {
+		case <-w.ticker.C:
+			w.advance()
+		case <-w.stopCh:
+			w.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (w *timingWheel) advance() {
+	w.mu.Lock()
+	slot := w.buckets[w.pos]
+	w.pos = (w.pos + 1) % len(w.buckets)
+	var due []*wheelTask
+	for e := slot.Front(); e != nil; {
+		next := e.Next()
+		task := e.Value.(*wheelTask)
+		switch {
+		case task.cancelled:
+			slot.Remove(e)
+		case task.remainingRounds <= 0:
+			due = append(due, task)
+			slot.Remove(e)
+		default:
+			task.remainingRounds--
+		}
+		e = next
+	}
+	w.mu.Unlock()
+	for _, task := range due {
+		go task.fn()
+	}
+}
+
+// After schedules fn to run once after d has elapsed and returns a function
+// that cancels it.
+func (w *timingWheel) After(d time.Duration, fn func()) func() {
+	if d < 0 {
+		d = 0
+	}
+	ticks := int(d / w.tick)
+	slots := len(w.buckets)
+	w.mu.Lock()
+	slot := (w.pos + ticks) % slots
+	task := &wheelTask{remainingRounds: ticks / slots, fn: fn}
+	w.buckets[slot].PushBack(task)
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		task.cancelled = true
+		w.mu.Unlock()
+	}
+}
+
+// defaultReconnectWheel is shared by every WsConn's reconnect loop.
+var defaultReconnectWheel = newTimingWheel(100*time.Millisecond, 600)
@@ -0,0 +1,45 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimingWheelAfterFires(t *testing.T) {
+	w := newTimingWheel(10*time.Millisecond, 50)
+	done := make(chan struct{})
+	w.After(30*time.Millisecond, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("After callback did not fire")
+	}
+}
+
+func TestTimingWheelAfterCancel(t *testing.T) {
+	w := newTimingWheel(10*time.Millisecond, 50)
+	fired := make(chan struct{})
+	cancel := w.After(30*time.Millisecond, func() { close(fired) })
+	cancel()
+
+	select {
+	case <-fired:
+		t.Fatal("cancelled task fired")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+const (
+	headerCodec    = "X-OpenIM-Codec"
+	headerCompress = "X-OpenIM-Compress"
+)
+
+// "pb" isn't registered: GeneralWsReq/GeneralWsResp have no generated
+// protobuf types in this tree yet, so there's nothing for a ProtoEncoder to
+// marshal. Add it here once that codegen step lands.
+var encoderRegistry = map[string]func() Encoder{
+	"gob":  func() Encoder { return NewGobEncoder() },
+	"json": func() Encoder { return NewJSONEncoder() },
+}
+
+var compressorRegistry = map[string]func() Compressor{
+	"gzip": func() Compressor { return NewGzipCompressor() },
+	"none": func() Compressor { return NewNoneCompressor() },
+}
+
+func registeredCodecNames() []string {
+	names := make([]string, 0, len(encoderRegistry))
+	for name := range encoderRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func registeredCompressorNames() []string {
+	names := make([]string, 0, len(compressorRegistry))
+	for name := range compressorRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (u *WsConn) negotiateCodec(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	if name := resp.Header.Get(headerCodec); name != "" {
+		if build, ok := encoderRegistry[name]; ok {
+			u.encoder = build()
+		}
+	}
+	if name := resp.Header.Get(headerCompress); name != "" {
+		if name == "none" {
+			u.IsCompression = false
+			return
+		}
+		if build, ok := compressorRegistry[name]; ok {
+			u.compressor = build()
+			u.IsCompression = true
+		}
+	}
+}
+
+type jsonEncoder struct{}
+
+func NewJSONEncoder() Encoder {
+	return jsonEncoder{}
+}
+
+func (jsonEncoder) Encode(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (jsonEncoder) Decode(data []byte, target interface{}) error {
+	return json.Unmarshal(data, target)
+}
+
+type noneCompressor struct{}
+
+func NewNoneCompressor() Compressor {
+	return noneCompressor{}
+}
+
+func (noneCompressor) Compress(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (noneCompressor) DeCompress(data []byte) ([]byte, error) {
+	return data, nil
+}
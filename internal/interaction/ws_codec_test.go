@@ -0,0 +1,62 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegisteredCodecNamesExcludesPb(t *testing.T) {
+	for _, name := range registeredCodecNames() {
+		if name == "pb" {
+			t.Fatal("\"pb\" must not be advertised: GeneralWsReq/GeneralWsResp have no protobuf encoder yet")
+		}
+	}
+}
+
+func TestNegotiateCodecSwitchesEncoderAndCompressor(t *testing.T) {
+	u := &WsConn{encoder: NewGobEncoder(), compressor: NewGzipCompressor(), IsCompression: true}
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set(headerCodec, "json")
+	resp.Header.Set(headerCompress, "none")
+
+	u.negotiateCodec(resp)
+
+	if _, ok := u.encoder.(jsonEncoder); !ok {
+		t.Fatalf("expected encoder to switch to jsonEncoder, got %T", u.encoder)
+	}
+	if u.IsCompression {
+		t.Fatal("expected IsCompression to be disabled when server picks \"none\"")
+	}
+}
+
+func TestNegotiateCodecIgnoresUnknownNames(t *testing.T) {
+	encoder := NewGobEncoder()
+	u := &WsConn{encoder: encoder}
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set(headerCodec, "pb")
+
+	u.negotiateCodec(resp)
+
+	if u.encoder != encoder {
+		t.Fatal("expected negotiateCodec to leave the encoder unchanged for an unregistered name")
+	}
+}
+
+func TestNegotiateCodecNilResponse(t *testing.T) {
+	u := &WsConn{encoder: NewGobEncoder()}
+	u.negotiateCodec(nil)
+}
@@ -15,9 +15,7 @@
 package interaction
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"net/http"
@@ -29,6 +27,8 @@ import (
 	"open_im_sdk/sdk_struct"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -36,19 +36,32 @@ import (
 const writeTimeoutSeconds = 30
 
 type WsConn struct {
-	stateMutex     sync.Mutex
-	conn           LongConn
-	loginStatus    int32
-	listener       open_im_sdk_callback.OnConnListener
-	encoder        Encoder
-	compressor     Compressor
-	token          string
-	loginUserID    string
-	IsCompression  bool
-	ConversationCh chan common.Cmd2Value
-	tokenErrCode   int32
+	stateMutex      sync.Mutex
+	conn            LongConn
+	loginStatus     int32
+	listener        open_im_sdk_callback.OnConnListener
+	encoder         Encoder
+	compressor      Compressor
+	token           string
+	loginUserID     string
+	IsCompression   bool
+	ConversationCh  chan common.Cmd2Value
+	tokenErrCode    int32
+	transport       TransportKind
+	downgradedAt    int64    // unix nano of the last auto-downgrade to TransportLongPoll, atomic; 0 if never
+	pendingReqs     sync.Map // msgIncr -> *wsPendingRequest
+	reconnectPolicy ReconnectPolicy
+	metrics         ConnMetrics
+	lastPingSentAt  int64 // unix nano, atomic
 }
 
+// websocketRetryInterval bounds how long a connection stays downgraded to
+// long-polling before ReConn tries websocket again; the infrastructure that
+// caused the downgrade (a proxy, a firewall) may no longer be in the path.
+const websocketRetryInterval = 10 * time.Minute
+
+type WsConnOption func(*WsConn)
+
 func (u *WsConn) IsInterruptReconnection() bool {
 	if u.tokenErrCode != 0 {
 		return true
@@ -56,15 +69,28 @@ func (u *WsConn) IsInterruptReconnection() bool {
 	return false
 }
 
-func NewWsConn(listener open_im_sdk_callback.OnConnListener, token string, loginUserID string, isCompression bool, conversationCh chan common.Cmd2Value) *WsConn {
+func NewWsConn(listener open_im_sdk_callback.OnConnListener, token string, loginUserID string, isCompression bool, conversationCh chan common.Cmd2Value, transport TransportKind, opts ...WsConnOption) *WsConn {
 	ctx := context.WithValue(context.Background(), "operationID", utils.OperationIDGenerator()) // todo
 	p := WsConn{listener: listener, token: token, loginUserID: loginUserID, IsCompression: isCompression, ConversationCh: conversationCh,
-		encoder: NewGobEncoder(), compressor: NewGzipCompressor()}
-	p.conn = NewWebSocket(WebSocket)
-	_, _, _ = p.ReConn(ctx)
+		encoder: NewGobEncoder(), compressor: NewGzipCompressor(), transport: transport, reconnectPolicy: DefaultReconnectPolicy()}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	p.conn = newLongConn(p.transport)
+	_, _, err := p.ReConn(ctx)
+	if shouldStartReconnectLoop(err == nil, p.IsInterruptReconnection(), p.LoginStatus()) {
+		p.StartReconnectLoop(ctx)
+	}
 	return &p
 }
 
+// shouldStartReconnectLoop decides whether a failed initial dial should hand
+// off to the background reconnect loop, as opposed to a permanent failure
+// (token error, kicked offline) that retrying can't fix.
+func shouldStartReconnectLoop(dialOk bool, interrupted bool, loginStatus int32) bool {
+	return !dialOk && !interrupted && loginStatus != constant.TokenFailedKickedOffline
+}
+
 func (u *WsConn) CloseConn(ctx context.Context) error {
 	u.Lock()
 	defer u.Unlock()
@@ -84,6 +110,9 @@ func (u *WsConn) LoginStatus() int32 {
 }
 
 func (u *WsConn) SetLoginStatus(loginState int32) {
+	if u.metrics != nil && u.loginStatus != loginState {
+		u.metrics.OnLoginStatusChange(u.loginStatus, loginState)
+	}
 	u.loginStatus = loginState
 }
 
@@ -106,6 +135,7 @@ func (u *WsConn) SendPingMsg() error {
 	if err != nil {
 		return utils.Wrap(err, "SetWriteDeadline failed")
 	}
+	atomic.StoreInt64(&u.lastPingSentAt, time.Now().UnixNano())
 	err = u.conn.WriteMessage(websocket.PingMessage, []byte(ping))
 	if err != nil {
 		return utils.Wrap(err, "WriteMessage failed")
@@ -113,6 +143,24 @@ func (u *WsConn) SendPingMsg() error {
 	return nil
 }
 
+// OnPongReceived should be called by the read loop's pong handler as soon as a pong arrives.
+func (u *WsConn) OnPongReceived() {
+	if u.metrics == nil {
+		return
+	}
+	sentAt := atomic.LoadInt64(&u.lastPingSentAt)
+	if sentAt == 0 {
+		return
+	}
+	u.metrics.OnPingRTT(time.Duration(time.Now().UnixNano() - sentAt))
+}
+
+func (u *WsConn) OnBytesRead(n int) {
+	if u.metrics != nil {
+		u.metrics.OnBytesRead(n)
+	}
+}
+
 func (u *WsConn) SetWriteTimeout(timeout int) error {
 	//return u.conn.SetWriteDeadline(time.Now().Add(time.Duration(timeout) * time.Second))
 	return u.conn.SetWriteTimeout(timeout)
@@ -146,9 +194,15 @@ func (u *WsConn) writeBinaryMsg(msg GeneralWsReq) error {
 			if err != nil {
 				return utils.Wrap(err, "")
 			}
+			if u.metrics != nil && len(data) > 0 {
+				u.metrics.OnCompressionRatio(float64(len(compressData)) / float64(len(data)))
+			}
 		} else {
 			compressData = data
 		}
+		if u.metrics != nil {
+			u.metrics.OnBytesWritten(len(compressData))
+		}
 		return utils.Wrap(u.conn.WriteMessage(websocket.BinaryMessage, compressData), "")
 	} else {
 		return utils.Wrap(errors.New("conn==nil"), "")
@@ -156,11 +210,8 @@ func (u *WsConn) writeBinaryMsg(msg GeneralWsReq) error {
 }
 
 func (u *WsConn) decodeBinaryWs(message []byte) (*GeneralWsResp, error) {
-	buff := bytes.NewBuffer(message)
-	dec := gob.NewDecoder(buff)
 	var data GeneralWsResp
-	err := dec.Decode(&data)
-	if err != nil {
+	if err := u.encoder.Decode(message, &data); err != nil {
 		return nil, utils.Wrap(err, "")
 	}
 	return &data, nil
@@ -189,8 +240,65 @@ func (u *WsConn) IsFatalError(err error) bool {
 
 func (u *WsConn) ReConn(ctx context.Context) (bool, bool, error) {
 	u.stateMutex.Lock()
-	u.tokenErrCode = 0
 	defer u.stateMutex.Unlock()
+	u.failPendingRequests(errors.New("ws conn is reconnecting"))
+
+	if u.transport == TransportLongPoll && u.downgradedSince() > websocketRetryInterval {
+		log.Debug("retrying websocket after a long-poll downgrade", u.downgradedSince().String())
+		u.transport = TransportWebSocket
+		u.conn = newLongConn(u.transport)
+	}
+
+	ok, kicked, err := u.dialOnce(ctx)
+	if err != nil && u.shouldDowngradeTransport(err) {
+		log.Debug("websocket dial looks like an infrastructure failure, falling back to long-polling transport", err.Error())
+		u.transport = TransportLongPoll
+		atomic.StoreInt64(&u.downgradedAt, time.Now().UnixNano())
+		u.conn = newLongConn(u.transport)
+		ok, kicked, err = u.dialOnce(ctx)
+	}
+	if u.transport == TransportWebSocket && err == nil {
+		atomic.StoreInt64(&u.downgradedAt, 0)
+	}
+	return ok, kicked, err
+}
+
+// downgradedSince returns how long ago this conn was auto-downgraded to
+// long-polling, or 0 if it never was.
+func (u *WsConn) downgradedSince() time.Duration {
+	at := atomic.LoadInt64(&u.downgradedAt)
+	if at == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, at))
+}
+
+// shouldDowngradeTransport reports whether a dial failure looks like a
+// websocket upgrade being blocked by infrastructure (proxy, firewall, a 403
+// without a token error code) rather than a transient network error that a
+// plain retry over websocket would recover from.
+func (u *WsConn) shouldDowngradeTransport(err error) bool {
+	if u.transport != TransportWebSocket {
+		return false
+	}
+	if u.tokenErrCode != 0 {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "bad handshake") || strings.Contains(msg, "403")
+}
+
+func (u *WsConn) dialOnce(ctx context.Context) (ok bool, kicked bool, err error) {
+	start := time.Now()
+	if u.metrics != nil {
+		u.metrics.OnDialStart()
+	}
+	defer func() {
+		if u.metrics != nil {
+			u.metrics.OnDialEnd(err == nil, time.Since(start))
+		}
+	}()
+	u.tokenErrCode = 0
 	if !u.conn.IsNil() {
 		//log.NewWarn(operationID, "close conn, ", u.conn, u.conn.LocalAddr())
 		err := u.conn.Close()
@@ -206,10 +314,12 @@ func (u *WsConn) ReConn(ctx context.Context) (bool, bool, error) {
 
 	url := fmt.Sprintf("%s?sendID=%s&token=%s&platformID=%d&operationID=%s", sdk_struct.SvrConf.WsAddr, u.loginUserID, u.token, sdk_struct.SvrConf.Platform, ctx.Value("operationID").(string))
 	//log.Info(operationID, "ws connect begin, dail: ", url)
-	var header http.Header
+	header := http.Header{}
 	if u.IsCompression {
-		header = http.Header{"compression": []string{"gzip"}}
+		header.Set("compression", "gzip") // legacy header, kept for servers that predate codec negotiation
 	}
+	header.Set(headerCodec, strings.Join(registeredCodecNames(), ","))
+	header.Set(headerCompress, strings.Join(registeredCompressorNames(), ","))
 	//conn, httpResp, err := u.websocket.DefaultDialer.Dial(url, header)
 	httpResp, err := u.conn.Dial(url, header)
 	//log.Info(operationID, "ws connect end, dail : ", url)
@@ -269,8 +379,34 @@ func (u *WsConn) ReConn(ctx context.Context) (bool, bool, error) {
 			return true, false, utils.Wrap(err, errMsg)
 		}
 	}
+	u.negotiateCodec(httpResp)
 	u.listener.OnConnectSuccess()
 	u.loginStatus = constant.LoginSuccess
+	go u.readLoop(u.conn)
 
 	return true, false, nil
 }
+
+// readLoop owns conn until ReadMessage returns an error (conn closed by
+// CloseConn/ReConn/Close, or a real read failure). Every decoded
+// GeneralWsResp is handed to dispatchResp; frames nothing is waiting on are
+// dropped, since routing unsolicited server pushes is out of scope here.
+func (u *WsConn) readLoop(conn LongConn) {
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType == websocket.PongMessage {
+			u.OnPongReceived()
+			continue
+		}
+		u.OnBytesRead(len(message))
+		resp, err := u.decodeBinaryWs(message)
+		if err != nil {
+			log.Debug("readLoop: decodeBinaryWs failed", err.Error())
+			continue
+		}
+		u.dispatchResp(resp)
+	}
+}
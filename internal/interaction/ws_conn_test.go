@@ -0,0 +1,52 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShouldDowngradeTransport(t *testing.T) {
+	cases := []struct {
+		name         string
+		transport    TransportKind
+		tokenErrCode int32
+		err          error
+		want         bool
+	}{
+		{"bad handshake on websocket", TransportWebSocket, 0, errors.New("websocket: bad handshake"), true},
+		{"403 without a token error code", TransportWebSocket, 0, errors.New("http 403 forbidden"), true},
+		{"already on long-poll", TransportLongPoll, 0, errors.New("bad handshake"), false},
+		{"token error takes priority", TransportWebSocket, 1, errors.New("bad handshake"), false},
+		{"connection refused is not an upgrade failure", TransportWebSocket, 0, errors.New("dial tcp: connection refused"), false},
+		{"eof is not an upgrade failure", TransportWebSocket, 0, errors.New("unexpected EOF"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u := &WsConn{transport: c.transport, tokenErrCode: c.tokenErrCode}
+			if got := u.shouldDowngradeTransport(c.err); got != c.want {
+				t.Fatalf("shouldDowngradeTransport(%q) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDowngradedSince(t *testing.T) {
+	u := &WsConn{}
+	if got := u.downgradedSince(); got != 0 {
+		t.Fatalf("downgradedSince() with no downgrade = %v, want 0", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ConnMetrics implementations must be safe for concurrent use; a nil ConnMetrics is valid.
+type ConnMetrics interface {
+	OnDialStart()
+	OnDialEnd(success bool, duration time.Duration)
+	OnBytesRead(n int)
+	OnBytesWritten(n int)
+	OnCompressionRatio(ratio float64)
+	OnPingRTT(rtt time.Duration)
+	OnReconnectAttempt(attempt int)
+	OnLoginStatusChange(from, to int32)
+}
+
+func WithConnMetrics(metrics ConnMetrics) WsConnOption {
+	return func(u *WsConn) {
+		u.metrics = metrics
+	}
+}
+
+type MetricsSnapshot struct {
+	DialCount            int64
+	DialFailures         int64
+	LastDialDuration     time.Duration
+	BytesRead            int64
+	BytesWritten         int64
+	LastCompressionRatio float64
+	LastPingRTT          time.Duration
+	ReconnectAttempts    int64
+	LoginStatus          int32
+}
+
+type InMemoryConnMetrics struct {
+	dialCount            int64
+	dialFailures         int64
+	lastDialDuration     int64
+	bytesRead            int64
+	bytesWritten         int64
+	lastCompressionRatio uint64 // math.Float64bits, atomic
+	lastPingRTT          int64
+	reconnectAttempts    int64
+	loginStatus          int32
+}
+
+func NewInMemoryConnMetrics() *InMemoryConnMetrics {
+	return &InMemoryConnMetrics{}
+}
+
+func (m *InMemoryConnMetrics) OnDialStart() {
+	atomic.AddInt64(&m.dialCount, 1)
+}
+
+func (m *InMemoryConnMetrics) OnDialEnd(success bool, duration time.Duration) {
+	atomic.StoreInt64(&m.lastDialDuration, int64(duration))
+	if !success {
+		atomic.AddInt64(&m.dialFailures, 1)
+	}
+}
+
+func (m *InMemoryConnMetrics) OnBytesRead(n int) {
+	atomic.AddInt64(&m.bytesRead, int64(n))
+}
+
+func (m *InMemoryConnMetrics) OnBytesWritten(n int) {
+	atomic.AddInt64(&m.bytesWritten, int64(n))
+}
+
+func (m *InMemoryConnMetrics) OnCompressionRatio(ratio float64) {
+	atomic.StoreUint64(&m.lastCompressionRatio, math.Float64bits(ratio))
+}
+
+func (m *InMemoryConnMetrics) OnPingRTT(rtt time.Duration) {
+	atomic.StoreInt64(&m.lastPingRTT, int64(rtt))
+}
+
+func (m *InMemoryConnMetrics) OnReconnectAttempt(attempt int) {
+	atomic.StoreInt64(&m.reconnectAttempts, int64(attempt))
+}
+
+func (m *InMemoryConnMetrics) OnLoginStatusChange(_, to int32) {
+	atomic.StoreInt32(&m.loginStatus, to)
+}
+
+func (m *InMemoryConnMetrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		DialCount:            atomic.LoadInt64(&m.dialCount),
+		DialFailures:         atomic.LoadInt64(&m.dialFailures),
+		LastDialDuration:     time.Duration(atomic.LoadInt64(&m.lastDialDuration)),
+		BytesRead:            atomic.LoadInt64(&m.bytesRead),
+		BytesWritten:         atomic.LoadInt64(&m.bytesWritten),
+		LastCompressionRatio: math.Float64frombits(atomic.LoadUint64(&m.lastCompressionRatio)),
+		LastPingRTT:          time.Duration(atomic.LoadInt64(&m.lastPingRTT)),
+		ReconnectAttempts:    atomic.LoadInt64(&m.reconnectAttempts),
+		LoginStatus:          atomic.LoadInt32(&m.loginStatus),
+	}
+}
+
+type ConnMetricsEvent func(event string, fields map[string]any)
+
+// funcConnMetrics bridges ConnMetrics callbacks to a user-supplied function.
+type funcConnMetrics struct {
+	emit ConnMetricsEvent
+}
+
+func NewFuncConnMetrics(emit ConnMetricsEvent) ConnMetrics {
+	return &funcConnMetrics{emit: emit}
+}
+
+func (f *funcConnMetrics) OnDialStart() {
+	f.emit("dial_start", nil)
+}
+
+func (f *funcConnMetrics) OnDialEnd(success bool, duration time.Duration) {
+	f.emit("dial_end", map[string]any{"success": success, "duration": duration})
+}
+
+func (f *funcConnMetrics) OnBytesRead(n int) {
+	f.emit("bytes_read", map[string]any{"bytes": n})
+}
+
+func (f *funcConnMetrics) OnBytesWritten(n int) {
+	f.emit("bytes_written", map[string]any{"bytes": n})
+}
+
+func (f *funcConnMetrics) OnCompressionRatio(ratio float64) {
+	f.emit("compression_ratio", map[string]any{"ratio": ratio})
+}
+
+func (f *funcConnMetrics) OnPingRTT(rtt time.Duration) {
+	f.emit("ping_rtt", map[string]any{"rtt": rtt})
+}
+
+func (f *funcConnMetrics) OnReconnectAttempt(attempt int) {
+	f.emit("reconnect_attempt", map[string]any{"attempt": attempt})
+}
+
+func (f *funcConnMetrics) OnLoginStatusChange(from, to int32) {
+	f.emit("login_status_change", map[string]any{"from": from, "to": to})
+}
@@ -0,0 +1,86 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type scriptedFrame struct {
+	messageType int
+	data        []byte
+}
+
+// scriptedLongConn replays a fixed sequence of frames and then fails
+// ReadMessage, mimicking a connection that's been closed.
+type scriptedLongConn struct {
+	*stubLongConn
+	frames []scriptedFrame
+	idx    int
+}
+
+func (s *scriptedLongConn) ReadMessage() (int, []byte, error) {
+	if s.idx >= len(s.frames) {
+		return 0, nil, io.EOF
+	}
+	f := s.frames[s.idx]
+	s.idx++
+	return f.messageType, f.data, nil
+}
+
+func TestReadLoopReportsBytesReadAndPongRTT(t *testing.T) {
+	metrics := NewInMemoryConnMetrics()
+	u := &WsConn{encoder: NewJSONEncoder(), metrics: metrics}
+	atomic.StoreInt64(&u.lastPingSentAt, time.Now().UnixNano())
+
+	payload, err := json.Marshal(GeneralWsResp{})
+	if err != nil {
+		t.Fatalf("marshal GeneralWsResp: %v", err)
+	}
+
+	conn := &scriptedLongConn{
+		stubLongConn: newStubLongConn(),
+		frames: []scriptedFrame{
+			{messageType: websocket.PongMessage},
+			{messageType: websocket.BinaryMessage, data: payload},
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		u.readLoop(conn)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("readLoop did not exit once the scripted frames ran out")
+	}
+
+	snap := metrics.Snapshot()
+	if snap.BytesRead != int64(len(payload)) {
+		t.Fatalf("BytesRead = %d, want %d", snap.BytesRead, len(payload))
+	}
+	if snap.LastPingRTT <= 0 {
+		t.Fatal("expected the pong frame to record a ping RTT via OnPongReceived")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"open_im_sdk/pkg/constant"
+	"time"
+)
+
+// ReconnectPolicy controls the backoff runReconnectLoop uses to redial
+// after a transient failure.
+type ReconnectPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// MaxElapsedTime bounds total retry time after the first attempt; zero means retry forever.
+	MaxElapsedTime time.Duration
+	// JitterFraction spreads each delay by +/- this fraction.
+	JitterFraction float64
+	// IsUnrecoverable, if set, stops the loop early for errors besides WsConn's own token errors.
+	IsUnrecoverable func(err error) bool
+}
+
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialDelay:   time.Second,
+		MaxDelay:       time.Minute,
+		Multiplier:     1.8,
+		MaxElapsedTime: 0,
+		JitterFraction: 0.2,
+	}
+}
+
+func (p ReconnectPolicy) nextDelay(attempt int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt))
+	if max := float64(p.MaxDelay); p.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+	if p.JitterFraction > 0 {
+		jitter := delay * p.JitterFraction
+		delay += jitter*2*rand.Float64() - jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// reconnectAttemptListener is implemented by an OnConnListener that wants
+// retry-state callbacks; asserted for rather than added to OnConnListener
+// itself, since that interface lives outside this package.
+type reconnectAttemptListener interface {
+	OnReconnectAttempt(attempt int, nextDelay time.Duration)
+}
+
+func (u *WsConn) SetReconnectPolicy(policy ReconnectPolicy) {
+	u.stateMutex.Lock()
+	defer u.stateMutex.Unlock()
+	u.reconnectPolicy = policy
+}
+
+// StartReconnectLoop redials in the background until connected or no longer worth retrying.
+func (u *WsConn) StartReconnectLoop(ctx context.Context) {
+	go u.runReconnectLoop(ctx, u.reconnectPolicy)
+}
+
+func (u *WsConn) runReconnectLoop(ctx context.Context, policy ReconnectPolicy) {
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		if u.IsInterruptReconnection() || u.LoginStatus() == constant.TokenFailedKickedOffline {
+			return
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(start) > policy.MaxElapsedTime {
+			return
+		}
+
+		ok, _, err := u.ReConn(ctx)
+		if err == nil && ok {
+			return
+		}
+		if u.IsInterruptReconnection() {
+			return
+		}
+		if policy.IsUnrecoverable != nil && err != nil && policy.IsUnrecoverable(err) {
+			return
+		}
+
+		delay := policy.nextDelay(attempt)
+		if ral, ok := u.listener.(reconnectAttemptListener); ok {
+			ral.OnReconnectAttempt(attempt+1, delay)
+		}
+		if u.metrics != nil {
+			u.metrics.OnReconnectAttempt(attempt + 1)
+		}
+
+		done := make(chan struct{})
+		cancel := defaultReconnectWheel.After(delay, func() { close(done) })
+		select {
+		case <-done:
+		case <-ctx.Done():
+			cancel()
+			return
+		}
+	}
+}
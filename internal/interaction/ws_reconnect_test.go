@@ -0,0 +1,89 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"open_im_sdk/pkg/constant"
+	"testing"
+	"time"
+)
+
+func TestReconnectPolicyNextDelayNoJitter(t *testing.T) {
+	p := ReconnectPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   2,
+	}
+
+	if got := p.nextDelay(0); got != 100*time.Millisecond {
+		t.Fatalf("attempt 0: got %v, want %v", got, 100*time.Millisecond)
+	}
+	if got := p.nextDelay(1); got != 200*time.Millisecond {
+		t.Fatalf("attempt 1: got %v, want %v", got, 200*time.Millisecond)
+	}
+	if got := p.nextDelay(2); got != 400*time.Millisecond {
+		t.Fatalf("attempt 2: got %v, want %v", got, 400*time.Millisecond)
+	}
+}
+
+func TestReconnectPolicyNextDelayCapsAtMaxDelay(t *testing.T) {
+	p := ReconnectPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     300 * time.Millisecond,
+		Multiplier:   2,
+	}
+
+	if got := p.nextDelay(5); got != 300*time.Millisecond {
+		t.Fatalf("got %v, want capped %v", got, 300*time.Millisecond)
+	}
+}
+
+func TestReconnectPolicyNextDelayJitterStaysInRange(t *testing.T) {
+	p := ReconnectPolicy{
+		InitialDelay:   100 * time.Millisecond,
+		MaxDelay:       time.Second,
+		Multiplier:     1,
+		JitterFraction: 0.2,
+	}
+
+	for i := 0; i < 100; i++ {
+		d := p.nextDelay(0)
+		if d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("delay %v outside +/-20%% jitter range around 100ms", d)
+		}
+	}
+}
+
+func TestShouldStartReconnectLoop(t *testing.T) {
+	cases := []struct {
+		name        string
+		dialOk      bool
+		interrupted bool
+		loginStatus int32
+		want        bool
+	}{
+		{"failed dial, no interrupt, not kicked", false, false, 0, true},
+		{"successful dial", true, false, 0, false},
+		{"token error interrupts retrying", false, true, 0, false},
+		{"kicked offline", false, false, constant.TokenFailedKickedOffline, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shouldStartReconnectLoop(c.dialOk, c.interrupted, c.loginStatus); got != c.want {
+				t.Fatalf("shouldStartReconnectLoop(%v, %v, %v) = %v, want %v", c.dialOk, c.interrupted, c.loginStatus, got, c.want)
+			}
+		})
+	}
+}
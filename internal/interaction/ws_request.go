@@ -0,0 +1,88 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"context"
+	"errors"
+	"open_im_sdk/pkg/utils"
+)
+
+// wsPendingRequest is keyed by GeneralWsReq.MsgIncr in WsConn.pendingReqs.
+type wsPendingRequest struct {
+	respCh chan *GeneralWsResp
+	errCh  chan error
+}
+
+func (u *WsConn) SendAndWait(ctx context.Context, req GeneralWsReq) (*GeneralWsResp, error) {
+	if req.MsgIncr == "" {
+		req.MsgIncr = utils.OperationIDGenerator()
+	}
+	pending := &wsPendingRequest{
+		respCh: make(chan *GeneralWsResp, 1),
+		errCh:  make(chan error, 1),
+	}
+	u.pendingReqs.Store(req.MsgIncr, pending)
+	defer u.pendingReqs.Delete(req.MsgIncr)
+
+	if err := u.writeBinaryMsg(req); err != nil {
+		return nil, utils.Wrap(err, "writeBinaryMsg failed")
+	}
+
+	select {
+	case resp := <-pending.respCh:
+		if resp.ErrCode != 0 {
+			return resp, utils.Wrap(errors.New(resp.ErrMsg), utils.IntToString(int(resp.ErrCode)))
+		}
+		return resp, nil
+	case err := <-pending.errCh:
+		return nil, utils.Wrap(err, "SendAndWait")
+	case <-ctx.Done():
+		return nil, utils.Wrap(ctx.Err(), "SendAndWait")
+	}
+}
+
+// dispatchResp hands resp to the SendAndWait caller registered under its
+// MsgIncr, if any; the read loop should call this for every decoded
+// GeneralWsResp.
+func (u *WsConn) dispatchResp(resp *GeneralWsResp) bool {
+	if resp == nil || resp.MsgIncr == "" {
+		return false
+	}
+	v, ok := u.pendingReqs.Load(resp.MsgIncr)
+	if !ok {
+		return false
+	}
+	pending := v.(*wsPendingRequest)
+	select {
+	case pending.respCh <- resp:
+	default:
+	}
+	return true
+}
+
+// failPendingRequests completes every outstanding SendAndWait call with err;
+// called by ReConn so a dropped connection doesn't leave callers blocked.
+func (u *WsConn) failPendingRequests(err error) {
+	u.pendingReqs.Range(func(key, value interface{}) bool {
+		pending := value.(*wsPendingRequest)
+		select {
+		case pending.errCh <- err:
+		default:
+		}
+		u.pendingReqs.Delete(key)
+		return true
+	})
+}
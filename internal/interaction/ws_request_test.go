@@ -0,0 +1,107 @@
+// Copyright © 2023 OpenIM SDK.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interaction
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// stubLongConn is a LongConn that never touches the network; WriteMessage
+// records what was written so a test can react to it.
+type stubLongConn struct {
+	written chan []byte
+}
+
+func newStubLongConn() *stubLongConn {
+	return &stubLongConn{written: make(chan []byte, 10)}
+}
+
+func (s *stubLongConn) IsNil() bool                                      { return s == nil }
+func (s *stubLongConn) Close() error                                     { return nil }
+func (s *stubLongConn) Dial(string, http.Header) (*http.Response, error) { return nil, nil }
+func (s *stubLongConn) SetReadTimeout(int) error                         { return nil }
+func (s *stubLongConn) SetWriteTimeout(int) error                        { return nil }
+func (s *stubLongConn) ReadMessage() (int, []byte, error) {
+	select {}
+}
+func (s *stubLongConn) WriteMessage(_ int, data []byte) error {
+	s.written <- data
+	return nil
+}
+
+func TestSendAndWaitCompletesOnDispatchResp(t *testing.T) {
+	conn := newStubLongConn()
+	u := &WsConn{conn: conn, encoder: NewJSONEncoder()}
+
+	go func() {
+		data := <-conn.written
+		var req GeneralWsReq
+		if err := u.encoder.Decode(data, &req); err != nil {
+			return
+		}
+		u.dispatchResp(&GeneralWsResp{MsgIncr: req.MsgIncr})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := u.SendAndWait(ctx, GeneralWsReq{})
+	if err != nil {
+		t.Fatalf("SendAndWait failed: %v", err)
+	}
+	if resp.MsgIncr == "" {
+		t.Fatal("expected dispatched response to carry the request's MsgIncr")
+	}
+}
+
+func TestSendAndWaitTimesOutWithoutDispatch(t *testing.T) {
+	conn := newStubLongConn()
+	u := &WsConn{conn: conn, encoder: NewJSONEncoder()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := u.SendAndWait(ctx, GeneralWsReq{}); err == nil {
+		t.Fatal("expected SendAndWait to time out when nothing dispatches a response")
+	}
+}
+
+func TestFailPendingRequestsUnblocksWaiters(t *testing.T) {
+	conn := newStubLongConn()
+	u := &WsConn{conn: conn, encoder: NewJSONEncoder()}
+
+	started := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		close(started)
+		_, err := u.SendAndWait(context.Background(), GeneralWsReq{})
+		errCh <- err
+	}()
+
+	<-started
+	<-conn.written // SendAndWait has registered its pending entry by the time the write lands
+	u.failPendingRequests(errors.New("ws conn is reconnecting"))
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected failPendingRequests to complete the waiter with an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SendAndWait did not return after failPendingRequests")
+	}
+}